@@ -0,0 +1,113 @@
+// Package ajp implements the client side of the AJP13 protocol used
+// between a web server and a servlet container (e.g. mod_jk/mod_proxy_ajp
+// talking to Tomcat): the Forward Request/Response exchange, request body
+// streaming via GET_BODY_CHUNK, and the CPing/CPong liveness probe.
+package ajp
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// Client is an AJP13 connection to a single servlet container endpoint.
+type Client struct {
+	conn net.Conn
+}
+
+// Dial connects to an AJP13 endpoint.
+func Dial(ctx context.Context, network, address string, timeout time.Duration) (*Client, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// LocalAddr returns the local address of the underlying connection, as
+// used to default Request.RemoteAddr/RemoteHost when the caller does not
+// set them explicitly.
+func (c *Client) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// SetDeadline sets the read/write deadline applied to the next Do or
+// Ping call.
+func (c *Client) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// Do sends a Forward Request and returns the container's Response. If
+// ctx carries a deadline, it is applied to the connection before the
+// request is sent.
+func (c *Client) Do(ctx context.Context, req *Request) (*Response, error) {
+	res, _, err := c.do(ctx, req)
+	return res, err
+}
+
+// Timing breaks down how long each phase of a DoTimed call took.
+type Timing struct {
+	Write time.Duration
+	Read  time.Duration
+}
+
+// DoTimed behaves like Do but also reports how long sending the request
+// and reading the response each took, for callers that report per-phase
+// probe durations (e.g. a Prometheus exporter).
+func (c *Client) DoTimed(ctx context.Context, req *Request) (*Response, Timing, error) {
+	return c.do(ctx, req)
+}
+
+func (c *Client) do(ctx context.Context, req *Request) (*Response, Timing, error) {
+	var timing Timing
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.conn.SetDeadline(deadline); err != nil {
+			return nil, timing, err
+		}
+	}
+
+	if err := req.validate(); err != nil {
+		return nil, timing, err
+	}
+
+	writeStart := time.Now()
+	bodySent, err := req.sendRequest(c.conn)
+	if err != nil {
+		return nil, timing, err
+	}
+	timing.Write = time.Since(writeStart)
+
+	readStart := time.Now()
+	res, err := readResponse(c.conn, req.Body, bodySent)
+	timing.Read = time.Since(readStart)
+	if err != nil {
+		return nil, timing, err
+	}
+
+	return &res, timing, nil
+}
+
+// Ping sends a CPing and waits for a CPong reply. It is a lightweight
+// liveness probe distinct from a full Forward Request, mirroring how
+// mod_jk/mod_proxy_ajp probe workers. If ctx carries a deadline, it is
+// applied to the connection before the CPing is sent.
+func (c *Client) Ping(ctx context.Context) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	if err := sendCping(c.conn); err != nil {
+		return err
+	}
+
+	return readCpong(c.conn)
+}
@@ -0,0 +1,31 @@
+package ajp
+
+import (
+	"fmt"
+	"net"
+)
+
+// ProtocolError indicates a failure at the AJP13 protocol level rather than
+// the network level: the container sent bytes that do not conform to the
+// wire format (an unexpected packet direction or prefix code), or a request
+// carries something the format cannot encode (an unknown attribute name or
+// an empty method).
+type ProtocolError struct {
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	return e.Message
+}
+
+func protocolErrorf(format string, a ...interface{}) error {
+	return &ProtocolError{Message: fmt.Sprintf(format, a...)}
+}
+
+// IsTimeout reports whether err is a network timeout rather than a
+// protocol-level failure, so callers can tell "the container is slow"
+// apart from "the container sent garbage".
+func IsTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
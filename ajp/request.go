@@ -0,0 +1,290 @@
+package ajp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/lunixbochs/struc"
+)
+
+var reqHeaderCodes = map[string][]byte{
+	"accept":          {0xA0, 0x01},
+	"accept-charset":  {0xA0, 0x02},
+	"accept-encoding": {0xA0, 0x03},
+	"accept-language": {0xA0, 0x04},
+	"authorization":   {0xA0, 0x05},
+	"connection":      {0xA0, 0x06},
+	"content-type":    {0xA0, 0x07},
+	"content-length":  {0xA0, 0x08},
+	"cookie":          {0xA0, 0x09},
+	"cookie2":         {0xA0, 0x0A},
+	"host":            {0xA0, 0x0B},
+	"pragma":          {0xA0, 0x0C},
+	"referer":         {0xA0, 0x0D},
+	"user-agent":      {0xA0, 0x0E},
+}
+
+var reqAttrCodes = map[string][]byte{
+	"context":       {0x01}, // Not currently implemented
+	"servlet_path":  {0x02}, // Not currently implemented
+	"remote_user":   {0x03},
+	"auth_type":     {0x04}, // Basic, Digest
+	"query_string":  {0x05},
+	"route":         {0x06},
+	"ssl_cert":      {0x07},
+	"ssl_cipher":    {0x08},
+	"ssl_session":   {0x09},
+	"req_attribute": {0x0A}, // Name (the name of the attribut follows)
+	"ssl_key_size":  {0x0B},
+	"secret":        {0x0C},
+	"stored_method": {0x0D},
+}
+
+var methodCodes = map[string]int{
+	"OPTIONS":          1,
+	"GET":              2,
+	"HEAD":             3,
+	"POST":             4,
+	"PUT":              5,
+	"DELETE":           6,
+	"TRACE":            7,
+	"PROPFIND":         8,
+	"PROPPATCH":        9,
+	"MKCOL":            10,
+	"COPY":             11,
+	"MOVE":             12,
+	"LOCK":             13,
+	"UNLOCK":           14,
+	"ACL":              15,
+	"REPORT":           16,
+	"VERSION-CONTROL":  17,
+	"CHECKIN":          18,
+	"CHECKOUT":         19,
+	"UNCHECKOUT":       20,
+	"SEARCH":           21,
+	"MKWORKSPACE":      22,
+	"UPDATE":           23,
+	"LABEL":            24,
+	"MERGE":            25,
+	"BASELINE_CONTROL": 26,
+	"MKACTIVITY":       27,
+}
+
+const (
+	forwardRequestPrefix = 0x02
+	cpingRequestPrefix   = 0x0A
+
+	// unknownMethodCode is the AJP13 method byte for a method with no
+	// entry in methodCodes (e.g. PATCH). The container is expected to
+	// recover the real method from the stored_method attribute rather
+	// than the method byte itself.
+	unknownMethodCode = 0xFF
+
+	// Max size of a single request body chunk sent in a Data packet. The
+	// AJP packet length field is a uint16 capped at 8192 bytes total; 4
+	// bytes go to the 0x12 0x34 marker and outer length, 2 more to the
+	// chunk's own length prefix, leaving 8186 bytes for the chunk itself.
+	maxBodyChunkSize = 8186
+)
+
+// Header is a request or response header name/value pair.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Attribute is an AJP13 request attribute (e.g. remote_user, ssl_cert).
+type Attribute struct {
+	Code  []byte
+	Value string
+}
+
+// NewAttribute looks up the AJP13 attribute code for name (case
+// insensitive) and returns an Attribute carrying value. It returns a
+// *ProtocolError if name is not a known attribute.
+func NewAttribute(name, value string) (*Attribute, error) {
+	code, ok := reqAttrCodes[strings.ToLower(name)]
+	if !ok {
+		return nil, protocolErrorf("unknown attribute: %s", name)
+	}
+	return &Attribute{Code: code, Value: value}, nil
+}
+
+// Request is an AJP13 Forward Request.
+type Request struct {
+	Method     string
+	Uri        string
+	Protocol   string
+	RemoteAddr string
+	RemoteHost string
+	ServerName string
+	ServerPort int
+	IsSsl      bool
+	Headers    []*Header
+	Attributes []*Attribute
+	Body       []byte
+}
+
+// NewRequest builds a Request for the given method, URI and protocol.
+// Method is case-insensitive; it is upper-cased and validated on send.
+func NewRequest(method, uri, protocol string) *Request {
+	return &Request{
+		Method:   strings.ToUpper(method),
+		Uri:      uri,
+		Protocol: protocol,
+	}
+}
+
+// AddHeader appends a header, using the compact AJP13 code for well-known
+// header names when one exists.
+func (r *Request) AddHeader(name, value string) {
+	r.Headers = append(r.Headers, &Header{Name: strings.ToLower(name), Value: value})
+}
+
+type encodeBool struct {
+	Value bool `struc:"bool"`
+}
+
+type encodeInt8 struct {
+	Value int `struc:"uint8"`
+}
+
+type encodeInt16 struct {
+	Value int `struc:"int16"`
+}
+
+type encodeString struct {
+	Size      int    `struc:"uint16,sizeof=Value"`
+	Value     string `struc:[]byte`
+	Terminate []byte `struc:"[1]pad"`
+}
+
+func appendUint16(buf *bytes.Buffer, i int) {
+	struc.Pack(buf, &encodeInt16{Value: i})
+}
+
+func appendByte(buf *bytes.Buffer, i int) {
+	struc.Pack(buf, &encodeInt8{Value: i})
+}
+
+func appendString(buf *bytes.Buffer, s string) {
+	struc.Pack(buf, &encodeString{Value: s})
+}
+
+func appendBool(buf *bytes.Buffer, b bool) {
+	struc.Pack(buf, &encodeBool{Value: b})
+}
+
+func (r *Request) validate() error {
+	if r.Method == "" {
+		return protocolErrorf("method is required")
+	}
+	return nil
+}
+
+// sendRequest writes the Forward Request packet, followed by the first
+// body chunk (if any). It returns the number of body bytes sent inline,
+// so the caller can pick up from the right offset when the container
+// asks for the rest via GET_BODY_CHUNK.
+func (r *Request) sendRequest(w io.Writer) (int, error) {
+	var err error
+
+	method := strings.ToUpper(r.Method)
+	methodCode, ok := methodCodes[method]
+	if !ok {
+		methodCode = unknownMethodCode
+	}
+
+	var buf bytes.Buffer
+	appendByte(&buf, forwardRequestPrefix)
+	appendByte(&buf, methodCode)
+	appendString(&buf, r.Protocol)
+	appendString(&buf, r.Uri)
+	appendString(&buf, r.RemoteAddr)
+	appendString(&buf, r.RemoteHost)
+	appendString(&buf, r.ServerName)
+	appendUint16(&buf, r.ServerPort)
+	appendBool(&buf, r.IsSsl)
+
+	// Headers
+	appendUint16(&buf, len(r.Headers))
+
+	for _, hdr := range r.Headers {
+		if reqHeaderCodes[strings.ToLower(hdr.Name)] != nil {
+			buf.Write(reqHeaderCodes[strings.ToLower(hdr.Name)])
+		} else {
+			appendString(&buf, strings.ToLower(hdr.Name))
+		}
+		appendString(&buf, hdr.Value)
+	}
+
+	// Attributes
+	for _, attr := range r.Attributes {
+		buf.Write(attr.Code)
+		appendString(&buf, attr.Value)
+	}
+	if methodCode == unknownMethodCode {
+		buf.Write(reqAttrCodes["stored_method"])
+		appendString(&buf, method)
+	}
+
+	buf.Write([]byte{0xff})
+
+	// Packet Format (Server->Container)
+	// Byte       0       1       2       3       4...(n+3)
+	// Contents   0x12    0x34    Data Length (n) Data
+	if _, err = w.Write([]byte{0x12, 0x34}); err != nil {
+		return 0, err
+	}
+	if err = struc.Pack(w, &encodeInt16{Value: buf.Len()}); err != nil {
+		return 0, err
+	}
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+
+	bodySent := 0
+	if len(r.Body) > 0 {
+		n := len(r.Body)
+		if n > maxBodyChunkSize {
+			n = maxBodyChunkSize
+		}
+		if err = sendBodyChunk(w, r.Body[0:n]); err != nil {
+			return 0, err
+		}
+		bodySent = n
+	}
+
+	return bodySent, nil
+}
+
+// sendBodyChunk writes one Data packet (Server->Container) carrying a
+// chunk of the request body. An empty chunk marks the end of the body.
+//
+// Packet Format (Server->Container)
+// Byte       0       1       2       3       4       5       6...
+// Contents   0x12    0x34    Data Length+2   Chunk Length    Chunk
+func sendBodyChunk(w io.Writer, chunk []byte) error {
+	var buf bytes.Buffer
+	appendUint16(&buf, len(chunk))
+	buf.Write(chunk)
+
+	if _, err := w.Write([]byte{0x12, 0x34}); err != nil {
+		return err
+	}
+	if err := struc.Pack(w, &encodeInt16{Value: buf.Len()}); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+// sendCping sends a CPing packet (Server->Container), a lightweight
+// liveness probe that expects a CPong reply without involving a full
+// Forward Request, mirroring mod_jk/mod_proxy_ajp worker probes.
+func sendCping(w io.Writer) error {
+	_, err := w.Write([]byte{0x12, 0x34, 0x00, 0x01, byte(cpingRequestPrefix)})
+	return err
+}
@@ -0,0 +1,300 @@
+package ajp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	sendBodyChunkPrefix int = 3
+	sendHeadersPrefix   int = 4
+	endResponsePrefix   int = 5
+	getBodyChunkPrefix  int = 6
+	cpongReplyPrefix    int = 9
+)
+
+const (
+	resContentType     string = "\xA0\x01"
+	resContentLanguage string = "\xA0\x02"
+	resContentLength   string = "\xA0\x03"
+	resDate            string = "\xA0\x04"
+	resLastModified    string = "\xA0\x05"
+	resLocation        string = "\xA0\x06"
+	resSetCookie       string = "\xA0\x07"
+	resSetCookie2      string = "\xA0\x08"
+	resServletEngine   string = "\xA0\x09"
+	resStatus          string = "\xA0\x0A"
+	resWwwAuthenticate string = "\xA0\x0B"
+)
+
+var resHeaderCodes = map[string]string{
+	resContentType:     "content-type",
+	resContentLanguage: "content-language",
+	resContentLength:   "content-length",
+	resDate:            "date",
+	resLastModified:    "last-modified",
+	resLocation:        "location",
+	resSetCookie:       "set-cookie",
+	resSetCookie2:      "set-cookie2",
+	resServletEngine:   "servlet-engine",
+	resStatus:          "status",
+	resWwwAuthenticate: "www-authenticate",
+}
+
+// Response is an AJP13 response to a Forward Request.
+type Response struct {
+	StatusCode    int
+	StatusMessage string
+	Headers       []*Header
+	Body          []byte
+}
+
+// Header returns the values of all response headers matching name
+// (case-insensitive).
+func (r *Response) Header(name string) []string {
+	var result []string
+	for _, hdr := range r.Headers {
+		if strings.ToLower(hdr.Name) == strings.ToLower(name) {
+			result = append(result, hdr.Value)
+		}
+	}
+	return result
+}
+
+func readByte(r io.Reader, n int) ([]byte, error) {
+	data := make([]byte, n)
+	_, err := io.ReadFull(r, data)
+	if err != nil {
+		return data, err
+	}
+	return data, err
+}
+
+func readBool(r io.Reader) (bool, error) {
+	var b bool
+	data, err := readByte(r, 1)
+	if err != nil {
+		return b, err
+	}
+	if data[0] == byte(0x00) {
+		b = false
+	} else {
+		b = true
+	}
+	return b, err
+}
+
+func readString(r io.Reader) (string, error) {
+	var len uint16
+	err := binary.Read(r, binary.BigEndian, &len)
+	if err != nil {
+		return "", err
+	}
+	return readStringN(r, int(len))
+}
+
+func readStringN(r io.Reader, len int) (string, error) {
+	data := make([]byte, len+1)
+	_, err := io.ReadFull(r, data)
+	if err != nil {
+		return string(data), err
+	}
+	return string(data[0:len]), err
+}
+
+func readUint16(r io.Reader) (int, error) {
+	var len uint16
+	err := binary.Read(r, binary.BigEndian, &len)
+	if err != nil {
+		return int(len), err
+	}
+	return int(len), err
+}
+
+func readUint8(r io.Reader) (int, error) {
+	var b uint8
+	err := binary.Read(r, binary.BigEndian, &b)
+	if err != nil {
+		return int(b), err
+	}
+	return int(b), err
+}
+
+// readResponse reads a Forward Response from rw, writing body chunks
+// back to rw as the container requests them via GET_BODY_CHUNK. bodySent
+// is the number of bytes of body already written inline by sendRequest,
+// so the GET_BODY_CHUNK loop below picks up from the right offset
+// instead of resending the start of the body.
+func readResponse(rw io.ReadWriter, body []byte, bodySent int) (Response, error) {
+	var res Response
+	var err error
+READ_RESPONSE:
+	for {
+		direction, err := readByte(rw, 2)
+		if err != nil {
+			return res, err
+		}
+		if bytes.Compare(direction, []byte("AB")) != 0 {
+			return res, protocolErrorf("unknown direction: %v", direction)
+		}
+		segmentSize, err := readUint16(rw)
+		if err != nil {
+			return res, err
+		}
+
+		prefix, err := readUint8(rw)
+		if err != nil {
+			return res, err
+		}
+		segmentSize -= 1
+
+		switch prefix {
+		case sendBodyChunkPrefix:
+			chunkLength, err := readUint16(rw)
+			if err != nil {
+				return res, err
+			}
+			segmentSize -= 2
+			chunk, err := readByte(rw, chunkLength)
+			if err != nil {
+				return res, err
+			}
+			res.Body = append(res.Body, chunk...)
+			if segmentSize != chunkLength {
+				_, err = readByte(rw, segmentSize-chunkLength)
+				if err != nil {
+					return res, err
+				}
+			}
+		case sendHeadersPrefix:
+			err = readResponseHeaders(rw, &res)
+			if err != nil {
+				return res, err
+			}
+		case endResponsePrefix:
+			_, err = readBool(rw)
+			if err != nil {
+				return res, err
+			}
+			// reuse := readBool(rw)
+			segmentSize -= 1
+			if segmentSize != 0 {
+				fmt.Fprintf(os.Stderr, "[WARNING] read remain unknown package\n")
+				_, err = readByte(rw, segmentSize-1)
+				if err != nil {
+					return res, err
+				}
+			}
+			break READ_RESPONSE
+		case getBodyChunkPrefix:
+			requestedLength, err := readUint16(rw)
+			if err != nil {
+				return res, err
+			}
+			segmentSize -= 2
+			if segmentSize != 0 {
+				_, err = readByte(rw, segmentSize)
+				if err != nil {
+					return res, err
+				}
+			}
+			remaining := body[bodySent:]
+			n := requestedLength
+			if n > len(remaining) {
+				n = len(remaining)
+			}
+			if n > maxBodyChunkSize {
+				n = maxBodyChunkSize
+			}
+			if err = sendBodyChunk(rw, remaining[0:n]); err != nil {
+				return res, err
+			}
+			bodySent += n
+		}
+	}
+	return res, err
+}
+
+func readResponseHeaders(r io.Reader, res *Response) error {
+	var err error
+	res.StatusCode, err = readUint16(r)
+	if err != nil {
+		return err
+	}
+
+	res.StatusMessage, err = readString(r)
+	if err != nil {
+		return err
+	}
+
+	num_headers, err := readUint16(r)
+	if err != nil {
+		return err
+	}
+
+	var header_name, header_value string
+	for i := 0; i < num_headers; i++ {
+		header_code, err := readByte(r, 2)
+		if err != nil {
+			return err
+		}
+		if header_code[0] == byte(0xa0) {
+			header_name = resHeaderCodes[string(header_code)]
+		} else {
+			len, err := readUint16(bytes.NewBuffer(header_code))
+			if err != nil {
+				return err
+			}
+			header_name, err = readStringN(r, len)
+			if err != nil {
+				return err
+			}
+		}
+		header_value, err = readString(r)
+		if err != nil {
+			return err
+		}
+		res.Headers = append(res.Headers, &Header{Name: header_name, Value: header_value})
+	}
+	return err
+}
+
+// readCpong waits for a CPong Reply packet (Container->Server) sent in
+// response to a CPing, as a lightweight liveness probe.
+func readCpong(r io.Reader) error {
+	direction, err := readByte(r, 2)
+	if err != nil {
+		return err
+	}
+	if bytes.Compare(direction, []byte("AB")) != 0 {
+		return protocolErrorf("unknown direction: %v", direction)
+	}
+
+	segmentSize, err := readUint16(r)
+	if err != nil {
+		return err
+	}
+
+	prefix, err := readUint8(r)
+	if err != nil {
+		return err
+	}
+	segmentSize -= 1
+
+	if prefix != cpongReplyPrefix {
+		return protocolErrorf("expected CPong reply (prefix %d), got prefix %d", cpongReplyPrefix, prefix)
+	}
+
+	if segmentSize != 0 {
+		_, err = readByte(r, segmentSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
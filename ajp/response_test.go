@@ -0,0 +1,109 @@
+package ajp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeConn is a minimal net.Conn backed by in-memory buffers, used to
+// drive readResponse against scripted container responses without a
+// real network connection.
+type fakeConn struct {
+	in  *bytes.Buffer
+	out bytes.Buffer
+}
+
+func (c *fakeConn) Read(b []byte) (int, error)         { return c.in.Read(b) }
+func (c *fakeConn) Write(b []byte) (int, error)        { return c.out.Write(b) }
+func (c *fakeConn) Close() error                       { return nil }
+func (c *fakeConn) LocalAddr() net.Addr                { return nil }
+func (c *fakeConn) RemoteAddr() net.Addr               { return nil }
+func (c *fakeConn) SetDeadline(t time.Time) error      { return nil }
+func (c *fakeConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *fakeConn) SetWriteDeadline(t time.Time) error { return nil }
+
+var _ net.Conn = (*fakeConn)(nil)
+
+func packetFrom(prefix byte, payload []byte) []byte {
+	body := append([]byte{prefix}, payload...)
+	var buf bytes.Buffer
+	buf.WriteString("AB")
+	binary.Write(&buf, binary.BigEndian, uint16(len(body)))
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+func getBodyChunkPacket(requestedLength uint16) []byte {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, requestedLength)
+	return packetFrom(byte(getBodyChunkPrefix), payload)
+}
+
+func endResponsePacket() []byte {
+	return packetFrom(byte(endResponsePrefix), []byte{0x00})
+}
+
+// sentBodyChunks parses the Data packets readResponse wrote back to conn
+// and concatenates their chunk payloads in order.
+func sentBodyChunks(t *testing.T, conn *fakeConn) []byte {
+	t.Helper()
+	var sent []byte
+	out := conn.out.Bytes()
+	for len(out) > 0 {
+		if len(out) < 6 || out[0] != 0x12 || out[1] != 0x34 {
+			t.Fatalf("malformed chunk packet: %v", out)
+		}
+		chunkLen := int(binary.BigEndian.Uint16(out[4:6]))
+		sent = append(sent, out[6:6+chunkLen]...)
+		out = out[6+chunkLen:]
+	}
+	return sent
+}
+
+// TestReadResponse_GetBodyChunkContinuesFromBodySent exercises the
+// GET_BODY_CHUNK branch of readResponse's state machine: the container
+// asks for the rest of a request body that sendRequest already started
+// sending inline. readResponse must resume from bodySent, not resend the
+// part already written.
+func TestReadResponse_GetBodyChunkContinuesFromBodySent(t *testing.T) {
+	body := bytes.Repeat([]byte("x"), 20000)
+
+	var script bytes.Buffer
+	script.Write(getBodyChunkPacket(8186))
+	script.Write(getBodyChunkPacket(8186))
+	script.Write(endResponsePacket())
+
+	conn := &fakeConn{in: bytes.NewBuffer(script.Bytes())}
+
+	if _, err := readResponse(conn, body, maxBodyChunkSize); err != nil {
+		t.Fatalf("readResponse: %v", err)
+	}
+
+	got := sentBodyChunks(t, conn)
+	want := body[maxBodyChunkSize:]
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GET_BODY_CHUNK resent/duplicated body: got %d bytes, want %d bytes continuing from offset %d", len(got), len(want), maxBodyChunkSize)
+	}
+}
+
+// TestReadResponse_GetBodyChunkNoInlineBody covers the simpler case where
+// sendRequest sent nothing inline (an empty body), so the first
+// GET_BODY_CHUNK must still get an empty chunk rather than erroring.
+func TestReadResponse_GetBodyChunkNoInlineBody(t *testing.T) {
+	var script bytes.Buffer
+	script.Write(getBodyChunkPacket(8186))
+	script.Write(endResponsePacket())
+
+	conn := &fakeConn{in: bytes.NewBuffer(script.Bytes())}
+
+	if _, err := readResponse(conn, nil, 0); err != nil {
+		t.Fatalf("readResponse: %v", err)
+	}
+
+	if got := sentBodyChunks(t, conn); len(got) != 0 {
+		t.Fatalf("expected no body bytes sent, got %d", len(got))
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"github.com/spyzhov/ajson"
+
+	"github.com/yteraoka/check_ajp/ajp"
+)
+
+// AssertionResult is the outcome of one response body/header assertion
+// (regex, JSONPath, XPath, size, or Content-Type). Each assertion
+// contributes its own status, message, and perfdata field so operators
+// can tell at a glance which assertion failed.
+type AssertionResult struct {
+	Name       string `json:"name"`
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message,omitempty"`
+	Perfdata   string `json:"perfdata,omitempty"`
+}
+
+// runAssertions evaluates every body/header assertion opts enables
+// against res.
+func runAssertions(res *ajp.Response, opts Options) []AssertionResult {
+	var results []AssertionResult
+
+	if opts.BodyRegex != "" {
+		results = append(results, assertBodyRegex("body-regex", res, opts.BodyRegex, true))
+	}
+	if opts.BodyNotRegex != "" {
+		results = append(results, assertBodyRegex("body-not-regex", res, opts.BodyNotRegex, false))
+	}
+	if opts.JsonPath != "" && opts.JsonPathMatch != "" {
+		results = append(results, assertJsonPath(res, opts.JsonPath, opts.JsonPathMatch))
+	}
+	if opts.XPath != "" {
+		results = append(results, assertXPath(res, opts.XPath))
+	}
+	if opts.MinSize > 0 {
+		results = append(results, assertMinSize(res, opts.MinSize))
+	}
+	if opts.MaxSize > 0 {
+		results = append(results, assertMaxSize(res, opts.MaxSize))
+	}
+	if opts.ExpectContentType != "" {
+		results = append(results, assertContentType(res, opts.ExpectContentType))
+	}
+
+	return results
+}
+
+func assertBodyRegex(name string, res *ajp.Response, pattern string, wantMatch bool) AssertionResult {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return AssertionResult{Name: name, StatusCode: NagiosUnknown, Message: fmt.Sprintf("invalid %s %q: %s", name, pattern, err)}
+	}
+
+	if re.Match(res.Body) != wantMatch {
+		verb := "did not match"
+		if !wantMatch {
+			verb = "matched"
+		}
+		return AssertionResult{
+			Name:       name,
+			StatusCode: NagiosCritical,
+			Message:    fmt.Sprintf("body %s %q", verb, pattern),
+			Perfdata:   fmt.Sprintf("'%s'=0", name),
+		}
+	}
+
+	return AssertionResult{Name: name, StatusCode: NagiosOk, Perfdata: fmt.Sprintf("'%s'=1", name)}
+}
+
+func assertJsonPath(res *ajp.Response, expr, matchPattern string) AssertionResult {
+	re, err := regexp.Compile(matchPattern)
+	if err != nil {
+		return AssertionResult{Name: "jsonpath", StatusCode: NagiosUnknown, Message: fmt.Sprintf("invalid jsonpath-match %q: %s", matchPattern, err)}
+	}
+
+	nodes, err := ajson.JSONPath(res.Body, expr)
+	if err != nil {
+		return AssertionResult{Name: "jsonpath", StatusCode: NagiosCritical, Message: fmt.Sprintf("jsonpath %q: %s", expr, err)}
+	}
+
+	matched := false
+	for _, node := range nodes {
+		value, err := node.Unpack()
+		if err != nil {
+			continue
+		}
+		if re.MatchString(fmt.Sprintf("%v", value)) {
+			matched = true
+			break
+		}
+	}
+
+	if !matched {
+		return AssertionResult{Name: "jsonpath", StatusCode: NagiosCritical, Message: fmt.Sprintf("no value at %q matched %q", expr, matchPattern), Perfdata: "'jsonpath'=0"}
+	}
+
+	return AssertionResult{Name: "jsonpath", StatusCode: NagiosOk, Perfdata: "'jsonpath'=1"}
+}
+
+func assertXPath(res *ajp.Response, expr string) AssertionResult {
+	var count int
+
+	xmlDoc, err := xmlquery.Parse(bytes.NewReader(res.Body))
+	if err == nil {
+		nodes, err := xmlquery.QueryAll(xmlDoc, expr)
+		if err != nil {
+			return AssertionResult{Name: "xpath", StatusCode: NagiosUnknown, Message: fmt.Sprintf("invalid xpath %q: %s", expr, err)}
+		}
+		count = len(nodes)
+	} else {
+		htmlDoc, err := htmlquery.Parse(bytes.NewReader(res.Body))
+		if err != nil {
+			return AssertionResult{Name: "xpath", StatusCode: NagiosUnknown, Message: fmt.Sprintf("could not parse body as XML or HTML: %s", err)}
+		}
+		nodes, err := htmlquery.QueryAll(htmlDoc, expr)
+		if err != nil {
+			return AssertionResult{Name: "xpath", StatusCode: NagiosUnknown, Message: fmt.Sprintf("invalid xpath %q: %s", expr, err)}
+		}
+		count = len(nodes)
+	}
+
+	if count == 0 {
+		return AssertionResult{Name: "xpath", StatusCode: NagiosCritical, Message: fmt.Sprintf("xpath %q matched nothing", expr), Perfdata: "'xpath'=0"}
+	}
+
+	return AssertionResult{Name: "xpath", StatusCode: NagiosOk, Perfdata: fmt.Sprintf("'xpath'=%d", count)}
+}
+
+func assertMinSize(res *ajp.Response, min int64) AssertionResult {
+	size := int64(len(res.Body))
+	perf := fmt.Sprintf("'min_size'=%dB;%d", size, min)
+	if size < min {
+		return AssertionResult{Name: "min-size", StatusCode: NagiosWarning, Message: fmt.Sprintf("response body %dB is smaller than minimum %dB", size, min), Perfdata: perf}
+	}
+	return AssertionResult{Name: "min-size", StatusCode: NagiosOk, Perfdata: perf}
+}
+
+func assertMaxSize(res *ajp.Response, max int64) AssertionResult {
+	size := int64(len(res.Body))
+	perf := fmt.Sprintf("'max_size'=%dB;;%d", size, max)
+	if size > max {
+		return AssertionResult{Name: "max-size", StatusCode: NagiosCritical, Message: fmt.Sprintf("response body %dB exceeds maximum %dB", size, max), Perfdata: perf}
+	}
+	return AssertionResult{Name: "max-size", StatusCode: NagiosOk, Perfdata: perf}
+}
+
+func assertContentType(res *ajp.Response, pattern string) AssertionResult {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return AssertionResult{Name: "content-type", StatusCode: NagiosUnknown, Message: fmt.Sprintf("invalid expect-content-type %q: %s", pattern, err)}
+	}
+
+	for _, v := range res.Header("content-type") {
+		if re.MatchString(v) {
+			return AssertionResult{Name: "content-type", StatusCode: NagiosOk, Perfdata: "'content_type'=1"}
+		}
+	}
+
+	return AssertionResult{
+		Name:       "content-type",
+		StatusCode: NagiosWarning,
+		Message:    fmt.Sprintf("Content-Type %v did not match %q", res.Header("content-type"), pattern),
+		Perfdata:   "'content_type'=0",
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/yteraoka/check_ajp/ajp"
+)
+
+func TestAssertBodyRegex(t *testing.T) {
+	res := &ajp.Response{Body: []byte("status: healthy")}
+
+	if r := assertBodyRegex("body-regex", res, "healthy", true); r.StatusCode != NagiosOk {
+		t.Errorf("expected a match to be OK, got %+v", r)
+	}
+	if r := assertBodyRegex("body-regex", res, "unhealthy", true); r.StatusCode != NagiosCritical {
+		t.Errorf("expected a missing match to be CRITICAL, got %+v", r)
+	}
+	if r := assertBodyRegex("body-not-regex", res, "unhealthy", false); r.StatusCode != NagiosOk {
+		t.Errorf("expected an absent pattern to be OK, got %+v", r)
+	}
+	if r := assertBodyRegex("body-not-regex", res, "healthy", false); r.StatusCode != NagiosCritical {
+		t.Errorf("expected a present forbidden pattern to be CRITICAL, got %+v", r)
+	}
+	if r := assertBodyRegex("body-regex", res, "(", true); r.StatusCode != NagiosUnknown {
+		t.Errorf("expected an invalid regex to be UNKNOWN, got %+v", r)
+	}
+}
+
+func TestAssertJsonPath(t *testing.T) {
+	res := &ajp.Response{Body: []byte(`{"status":[{"name":"db","healthy":true},{"name":"cache","healthy":false}]}`)}
+
+	if r := assertJsonPath(res, `$.status[?(@.name=='db')].healthy`, "true"); r.StatusCode != NagiosOk {
+		t.Errorf("expected the request's own example expression to match, got %+v", r)
+	}
+	if r := assertJsonPath(res, `$.status[?(@.name=='cache')].healthy`, "true"); r.StatusCode != NagiosCritical {
+		t.Errorf("expected a non-matching value to be CRITICAL, got %+v", r)
+	}
+	if r := assertJsonPath(res, `$.status[?(@.name=='db')].healthy`, "("); r.StatusCode != NagiosUnknown {
+		t.Errorf("expected an invalid jsonpath-match regex to be UNKNOWN, got %+v", r)
+	}
+	if r := assertJsonPath(&ajp.Response{Body: []byte("not json")}, "$.status", "true"); r.StatusCode != NagiosCritical {
+		t.Errorf("expected a jsonpath over a non-JSON body to be CRITICAL, got %+v", r)
+	}
+}
+
+func TestAssertXPath(t *testing.T) {
+	xml := &ajp.Response{Body: []byte(`<root><status>healthy</status></root>`)}
+	if r := assertXPath(xml, "//status"); r.StatusCode != NagiosOk {
+		t.Errorf("expected an XML match to be OK, got %+v", r)
+	}
+	if r := assertXPath(xml, "//missing"); r.StatusCode != NagiosCritical {
+		t.Errorf("expected no XML match to be CRITICAL, got %+v", r)
+	}
+
+	html := &ajp.Response{Body: []byte(`<html><body><div id="status">healthy</div></body></html>`)}
+	if r := assertXPath(html, "//div[@id='status']"); r.StatusCode != NagiosOk {
+		t.Errorf("expected an HTML match to be OK, got %+v", r)
+	}
+}
+
+func TestAssertMinSize(t *testing.T) {
+	res := &ajp.Response{Body: []byte("12345")}
+	if r := assertMinSize(res, 3); r.StatusCode != NagiosOk {
+		t.Errorf("expected body over the minimum to be OK, got %+v", r)
+	}
+	if r := assertMinSize(res, 10); r.StatusCode != NagiosWarning {
+		t.Errorf("expected body under the minimum to be WARNING, got %+v", r)
+	}
+}
+
+func TestAssertMaxSize(t *testing.T) {
+	res := &ajp.Response{Body: []byte("12345")}
+	if r := assertMaxSize(res, 10); r.StatusCode != NagiosOk {
+		t.Errorf("expected body under the maximum to be OK, got %+v", r)
+	}
+	if r := assertMaxSize(res, 3); r.StatusCode != NagiosCritical {
+		t.Errorf("expected body over the maximum to be CRITICAL, got %+v", r)
+	}
+}
+
+func TestAssertContentType(t *testing.T) {
+	res := &ajp.Response{Headers: []*ajp.Header{{Name: "Content-Type", Value: "application/json; charset=utf-8"}}}
+
+	if r := assertContentType(res, "^application/json"); r.StatusCode != NagiosOk {
+		t.Errorf("expected a matching Content-Type to be OK, got %+v", r)
+	}
+	if r := assertContentType(res, "^text/html"); r.StatusCode != NagiosWarning {
+		t.Errorf("expected a non-matching Content-Type to be WARNING, got %+v", r)
+	}
+	if r := assertContentType(res, "("); r.StatusCode != NagiosUnknown {
+		t.Errorf("expected an invalid expect-content-type regex to be UNKNOWN, got %+v", r)
+	}
+}
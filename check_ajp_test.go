@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestPingStatus(t *testing.T) {
+	cases := []struct {
+		name    string
+		elapsed float64
+		warn    float64
+		crit    float64
+		want    int
+	}{
+		{"well within thresholds", 0.1, 5.0, 10.0, NagiosOk},
+		{"exactly at warn", 5.0, 5.0, 10.0, NagiosOk},
+		{"past warn", 5.1, 5.0, 10.0, NagiosWarning},
+		{"exactly at crit", 10.0, 5.0, 10.0, NagiosWarning},
+		{"past crit", 10.1, 5.0, 10.0, NagiosCritical},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := pingStatus(c.elapsed, c.warn, c.crit)
+			if got != c.want {
+				t.Errorf("pingStatus(%v, %v, %v) = %v, want %v", c.elapsed, c.warn, c.crit, got, c.want)
+			}
+		})
+	}
+}
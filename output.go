@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+var statusPriority = map[int]int{
+	NagiosOk:       0,
+	NagiosWarning:  1,
+	NagiosUnknown:  2,
+	NagiosCritical: 3,
+}
+
+// aggregateStatus returns the worst status among probes, following the
+// conventional Nagios escalation order OK < WARNING < UNKNOWN < CRITICAL.
+func aggregateStatus(probes []Probe) int {
+	worst := NagiosOk
+	for _, p := range probes {
+		if statusPriority[p.NagiosStatus] > statusPriority[worst] {
+			worst = p.NagiosStatus
+		}
+	}
+	return worst
+}
+
+// foldAssertionStatus escalates status to the worst status among
+// assertions, following the same OK < WARNING < UNKNOWN < CRITICAL order.
+func foldAssertionStatus(status int, assertions []AssertionResult) int {
+	for _, a := range assertions {
+		if statusPriority[a.StatusCode] > statusPriority[status] {
+			status = a.StatusCode
+		}
+	}
+	return status
+}
+
+// printNagios renders probes as classic Nagios/Icinga plugin output: one
+// summary line with perfdata per target.
+func printNagios(probes []Probe) {
+	for _, p := range probes {
+		perfdata := fmt.Sprintf("time=%.6fs;;;%.6f size=%dB;;;0", p.ResponseTime, 0.0, p.ResponseBytes)
+		for _, a := range p.Assertions {
+			if a.Perfdata != "" {
+				perfdata += " " + a.Perfdata
+			}
+		}
+
+		fmt.Printf("AJP %s: %s - %d - %d bytes in %.3f second response time |%s\n",
+			NagiosStatus[p.NagiosStatus], p.Target, p.StatusCode, p.ResponseBytes, p.ResponseTime, perfdata)
+		if p.Message != "" {
+			fmt.Println(p.Message)
+		}
+		for _, a := range p.Assertions {
+			if a.Message != "" {
+				fmt.Printf("[%s] %s\n", a.Name, a.Message)
+			}
+		}
+	}
+}
+
+// printJSON renders probes as a JSON array, one object per target.
+func printJSON(probes []Probe) error {
+	out, err := json.MarshalIndent(probes, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// printPrometheus renders probes as Prometheus/OpenMetrics exposition
+// text, suitable for a blackbox-style scrape target.
+func printPrometheus(probes []Probe) {
+	fmt.Println("# HELP ajp_up Whether the AJP probe succeeded")
+	fmt.Println("# TYPE ajp_up gauge")
+	for _, p := range probes {
+		fmt.Printf("ajp_up{target=%q} %d\n", p.Target, boolToInt(p.Up))
+	}
+
+	fmt.Println("# HELP ajp_response_time_seconds Total AJP response time in seconds")
+	fmt.Println("# TYPE ajp_response_time_seconds gauge")
+	for _, p := range probes {
+		fmt.Printf("ajp_response_time_seconds{target=%q} %f\n", p.Target, p.ResponseTime)
+	}
+
+	fmt.Println("# HELP ajp_response_bytes Size of the AJP response body in bytes")
+	fmt.Println("# TYPE ajp_response_bytes gauge")
+	for _, p := range probes {
+		fmt.Printf("ajp_response_bytes{target=%q} %d\n", p.Target, p.ResponseBytes)
+	}
+
+	fmt.Println("# HELP ajp_status_code HTTP status code returned by the AJP target")
+	fmt.Println("# TYPE ajp_status_code gauge")
+	for _, p := range probes {
+		fmt.Printf("ajp_status_code{target=%q} %d\n", p.Target, p.StatusCode)
+	}
+
+	fmt.Println("# HELP ajp_probe_duration_seconds Duration of each probe phase")
+	fmt.Println("# TYPE ajp_probe_duration_seconds gauge")
+	for _, p := range probes {
+		fmt.Printf("ajp_probe_duration_seconds{target=%q,phase=\"connect\"} %f\n", p.Target, p.ConnectTime)
+		fmt.Printf("ajp_probe_duration_seconds{target=%q,phase=\"write\"} %f\n", p.Target, p.WriteTime)
+		fmt.Printf("ajp_probe_duration_seconds{target=%q,phase=\"read\"} %f\n", p.Target, p.ReadTime)
+	}
+
+	if hasAssertions(probes) {
+		fmt.Println("# HELP ajp_assertion_passed Whether a body/header assertion passed")
+		fmt.Println("# TYPE ajp_assertion_passed gauge")
+		for _, p := range probes {
+			for _, a := range p.Assertions {
+				fmt.Printf("ajp_assertion_passed{target=%q,assertion=%q} %d\n", p.Target, a.Name, boolToInt(a.StatusCode == NagiosOk))
+			}
+		}
+	}
+}
+
+func hasAssertions(probes []Probe) bool {
+	for _, p := range probes {
+		if len(p.Assertions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// report prints probes in the requested format and returns the exit
+// code the plugin should use.
+func report(probes []Probe, output string) int {
+	switch output {
+	case "json":
+		if err := printJSON(probes); err != nil {
+			fmt.Printf("AJP %s - %s\n", NagiosStatus[NagiosUnknown], err)
+			return NagiosUnknown
+		}
+	case "prometheus":
+		printPrometheus(probes)
+	default:
+		printNagios(probes)
+	}
+	return aggregateStatus(probes)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yteraoka/check_ajp/ajp"
+)
+
+// maxConcurrentProbes bounds how many targets from --targets-file are
+// probed at once.
+const maxConcurrentProbes = 10
+
+// Probe is the outcome of checking a single AJP target, independent of
+// how it is ultimately reported (Nagios text, JSON, or Prometheus).
+type Probe struct {
+	Target        string            `json:"target"`
+	Up            bool              `json:"up"`
+	StatusCode    int               `json:"status_code"`
+	ResponseBytes int               `json:"response_bytes"`
+	ResponseTime  float64           `json:"response_time_seconds"`
+	ConnectTime   float64           `json:"connect_time_seconds"`
+	WriteTime     float64           `json:"write_time_seconds"`
+	ReadTime      float64           `json:"read_time_seconds"`
+	NagiosStatus  int               `json:"-"`
+	Message       string            `json:"message,omitempty"`
+	Assertions    []AssertionResult `json:"assertions,omitempty"`
+}
+
+// Target is one row parsed from --targets-file.
+type Target struct {
+	Ipaddr string
+	Port   int
+	Uri    string
+	Vhost  string
+	Expect string
+}
+
+// parseTargetsFile reads targets, one per line, formatted as
+// "host:port[,uri,vhost,expect]". Blank lines and lines starting with
+// "#" are ignored.
+func parseTargetsFile(path string) ([]Target, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var targets []Target
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		hostport := strings.SplitN(fields[0], ":", 2)
+		if len(hostport) != 2 {
+			return nil, fmt.Errorf("invalid target %q: expected host:port", line)
+		}
+		port, err := strconv.Atoi(hostport[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %q: %s", line, err)
+		}
+
+		t := Target{Ipaddr: hostport[0], Port: port, Uri: "/"}
+		if len(fields) > 1 && fields[1] != "" {
+			t.Uri = fields[1]
+		}
+		if len(fields) > 2 {
+			t.Vhost = fields[2]
+		}
+		if len(fields) > 3 {
+			t.Expect = fields[3]
+		}
+		targets = append(targets, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// evaluateResponse maps a Response to a Nagios status and message,
+// applying the same expected-status-code and response-time-threshold
+// rules used by both the single-target and batch code paths.
+func evaluateResponse(res *ajp.Response, expect string, warn float64, responseTime float64) (int, string) {
+	statusTxt := strconv.Itoa(res.StatusCode)
+
+	if expect == "" {
+		if res.StatusCode >= 500 {
+			return NagiosCritical, fmt.Sprintf("Unexpected status code: %d", res.StatusCode)
+		}
+		if res.StatusCode >= 400 {
+			return NagiosWarning, fmt.Sprintf("Unexpected status code: %d", res.StatusCode)
+		}
+	} else {
+		status := NagiosWarning
+		for _, e := range strings.Split(expect, ",") {
+			if statusTxt == e {
+				status = NagiosOk
+			}
+		}
+		if status == NagiosWarning {
+			return NagiosWarning, fmt.Sprintf("Unexpected status code: %d", res.StatusCode)
+		}
+	}
+
+	if responseTime > warn {
+		return NagiosWarning, fmt.Sprintf("response time %.3fs exceeded warning threshold %.3fs", responseTime, warn)
+	}
+
+	return NagiosOk, ""
+}
+
+// probeTarget sends a request without a body or attributes to t,
+// inheriting method/protocol/ssl, headers and the connect/critical/warning
+// timeouts from opts. It is used for --targets-file batch checks.
+// --json-key/--json-value are not checked here; the body/header
+// assertions of runAssertions are.
+func probeTarget(t Target, opts Options) Probe {
+	p := Probe{Target: fmt.Sprintf("%s:%d", t.Ipaddr, t.Port)}
+
+	req := ajp.NewRequest(opts.Method, t.Uri, opts.Protocol)
+	req.IsSsl = opts.Ssl
+	req.ServerName = t.Ipaddr
+	req.ServerPort = t.Port
+
+	headers := append([]string{}, opts.Headers...)
+	if t.Vhost != "" {
+		headers = append(headers, "Host: "+t.Vhost)
+	}
+	for _, h := range headers {
+		req.Headers = append(req.Headers, parseHeaderOption(h))
+	}
+
+	remote := fmt.Sprintf("%s:%d", t.Ipaddr, t.Port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.Timeout)*time.Second)
+	defer cancel()
+
+	connectStart := time.Now()
+	client, err := ajp.Dial(ctx, "tcp", remote, time.Duration(opts.Timeout)*time.Second)
+	p.ConnectTime = time.Since(connectStart).Seconds()
+	if err != nil {
+		p.NagiosStatus = NagiosCritical
+		p.Message = err.Error()
+		return p
+	}
+	defer client.Close()
+
+	client.SetDeadline(time.Now().Add(time.Duration(opts.Crit) * time.Second))
+
+	localaddr := client.LocalAddr().String()
+	localaddr = localaddr[0:strings.Index(localaddr, ":")]
+	req.RemoteAddr = localaddr
+	req.RemoteHost = localaddr
+
+	res, timing, err := client.DoTimed(context.Background(), req)
+	p.WriteTime = timing.Write.Seconds()
+	p.ReadTime = timing.Read.Seconds()
+	p.ResponseTime = p.WriteTime + p.ReadTime
+	if err != nil {
+		p.NagiosStatus = NagiosCritical
+		if ajp.IsTimeout(err) {
+			p.Message = fmt.Sprintf("read timeout exceeded critical threshold %.3fs (%s)", opts.Crit, err)
+		} else {
+			p.Message = err.Error()
+		}
+		return p
+	}
+
+	p.Up = true
+	p.StatusCode = res.StatusCode
+	p.ResponseBytes = len(res.Body)
+
+	expect := t.Expect
+	if expect == "" {
+		expect = opts.Expect
+	}
+	p.NagiosStatus, p.Message = evaluateResponse(res, expect, opts.Warn, p.ResponseTime)
+
+	p.Assertions = runAssertions(res, opts)
+	p.NagiosStatus = foldAssertionStatus(p.NagiosStatus, p.Assertions)
+
+	return p
+}
+
+// probeTargets runs probeTarget over targets concurrently, bounded by
+// maxConcurrentProbes, and returns results in the same order as targets.
+func probeTargets(targets []Target, opts Options) []Probe {
+	results := make([]Probe, len(targets))
+	sem := make(chan struct{}, maxConcurrentProbes)
+	done := make(chan struct{})
+
+	for i, t := range targets {
+		i, t := i, t
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; done <- struct{}{} }()
+			results[i] = probeTarget(t, opts)
+		}()
+	}
+	for range targets {
+		<-done
+	}
+
+	return results
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yteraoka/check_ajp/ajp"
+)
+
+func TestEvaluateResponse(t *testing.T) {
+	cases := []struct {
+		name         string
+		statusCode   int
+		expect       string
+		warn         float64
+		responseTime float64
+		wantStatus   int
+	}{
+		{"no expect, 2xx", 200, "", 5.0, 0.1, NagiosOk},
+		{"no expect, 4xx", 404, "", 5.0, 0.1, NagiosWarning},
+		{"no expect, 5xx", 503, "", 5.0, 0.1, NagiosCritical},
+		{"expect matches", 201, "200,201", 5.0, 0.1, NagiosOk},
+		{"expect does not match", 500, "200,201", 5.0, 0.1, NagiosWarning},
+		{"over response time threshold", 200, "", 5.0, 5.1, NagiosWarning},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := &ajp.Response{StatusCode: c.statusCode}
+			status, _ := evaluateResponse(res, c.expect, c.warn, c.responseTime)
+			if status != c.wantStatus {
+				t.Errorf("evaluateResponse(%d, %q, %v, %v) = %v, want %v", c.statusCode, c.expect, c.warn, c.responseTime, status, c.wantStatus)
+			}
+		})
+	}
+}
+
+func writeTargetsFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "targets")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	return path
+}
+
+func TestParseTargetsFile(t *testing.T) {
+	path := writeTargetsFile(t, targetsFileFixture())
+	targets, err := parseTargetsFile(path)
+	if err != nil {
+		t.Fatalf("parseTargetsFile: %s", err)
+	}
+
+	want := []Target{
+		{Ipaddr: "10.0.0.1", Port: 8009, Uri: "/"},
+		{Ipaddr: "10.0.0.2", Port: 8009, Uri: "/health"},
+		{Ipaddr: "10.0.0.3", Port: 8009, Uri: "/health", Vhost: "app.example.com"},
+		{Ipaddr: "10.0.0.4", Port: 8009, Uri: "/health", Vhost: "app.example.com", Expect: "200"},
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("got %d targets, want %d: %+v", len(targets), len(want), targets)
+	}
+	for i, w := range want {
+		if targets[i] != w {
+			t.Errorf("target %d = %+v, want %+v", i, targets[i], w)
+		}
+	}
+}
+
+func targetsFileFixture() string {
+	return "" +
+		"# comment lines and blank lines are ignored\n" +
+		"\n" +
+		"10.0.0.1:8009\n" +
+		"10.0.0.2:8009,/health\n" +
+		"10.0.0.3:8009,/health,app.example.com\n" +
+		"10.0.0.4:8009,/health,app.example.com,200\n"
+}
+
+func TestParseTargetsFile_MissingPort(t *testing.T) {
+	path := writeTargetsFile(t, "10.0.0.1\n")
+	if _, err := parseTargetsFile(path); err == nil {
+		t.Fatal("expected an error for a target with no port, got nil")
+	}
+}
+
+func TestParseTargetsFile_InvalidPort(t *testing.T) {
+	path := writeTargetsFile(t, "10.0.0.1:notaport\n")
+	if _, err := parseTargetsFile(path); err == nil {
+		t.Fatal("expected an error for a non-numeric port, got nil")
+	}
+}